@@ -0,0 +1,304 @@
+package complexity
+
+import (
+	"fmt"
+
+	"github.com/graph-gophers/graphql-go/types"
+	"gitlab.com/infor-cloud/martian-cloud/tharsis/graphql-query-complexity/internal/common"
+	"gitlab.com/infor-cloud/martian-cloud/tharsis/graphql-query-complexity/internal/query"
+)
+
+// defaultMaxAssumedListSize is used in place of
+// AnalyzerOptions.MaxAssumedListSize when it is left unset (zero).
+const defaultMaxAssumedListSize = 100
+
+// defaultMaxFragmentDepth is used in place of
+// AnalyzerOptions.MaxFragmentDepth when it is left unset (zero).
+const defaultMaxFragmentDepth = 32
+
+// rootTypeNames maps an operation type to the conventional name of its root
+// object type in the schema.
+var rootTypeNames = map[types.OperationType]string{
+	query.Query:        "Query",
+	query.Mutation:     "Mutation",
+	query.Subscription: "Subscription",
+}
+
+// Analyzer calculates query complexity using per-field costs declared in a
+// GraphQL SDL schema via the @cost(complexity: Int, multipliers: [String!],
+// useMultipliers: Boolean) or @complexity(value: Int, multipliers: [String!])
+// directive, instead of the flat fieldOverrides map GetQueryComplexity
+// accepts. Overrides are resolved as "TypeName.fieldName" internally, so two
+// unrelated fields that happen to share a name (e.g. two different "parent"
+// fields) no longer collide.
+type Analyzer struct {
+	types              map[string]*common.ObjectType
+	maxAssumedListSize int
+	maxFragmentDepth   int
+}
+
+// AnalyzerOptions configures NewAnalyzerWithOptions.
+type AnalyzerOptions struct {
+	// MaxAssumedListSize is the list-size multiplier assumed for a
+	// multiplier argument (e.g. `first: $count`) backed by neither a
+	// supplied variable value nor a schema-declared default, so complexity
+	// is never silently under-counted for an effectively unbounded
+	// paginated field. Zero uses the package default.
+	MaxAssumedListSize int
+
+	// MaxFragmentDepth bounds how deeply fragment spreads may nest inside
+	// one another (fragment A spreads B spreads C, ...), so a pathological
+	// chain of spreads can't exhaust the stack even if it isn't a cycle.
+	// Zero uses the package default.
+	MaxFragmentDepth int
+}
+
+// NewAnalyzer parses schemaSDL and returns an Analyzer ready to score
+// queries against it, using the package's default MaxAssumedListSize.
+func NewAnalyzer(schemaSDL string) (*Analyzer, error) {
+	return NewAnalyzerWithOptions(schemaSDL, nil)
+}
+
+// NewAnalyzerWithOptions behaves like NewAnalyzer, but also lets callers
+// configure how an unresolvable multiplier argument is priced via opts.
+func NewAnalyzerWithOptions(schemaSDL string, opts *AnalyzerOptions) (*Analyzer, error) {
+	objectTypes, err := common.ParseSchema(schemaSDL)
+	if err != nil {
+		return nil, err
+	}
+
+	maxAssumedListSize := defaultMaxAssumedListSize
+	if opts != nil && opts.MaxAssumedListSize != 0 {
+		maxAssumedListSize = opts.MaxAssumedListSize
+	}
+	maxFragmentDepth := defaultMaxFragmentDepth
+	if opts != nil && opts.MaxFragmentDepth != 0 {
+		maxFragmentDepth = opts.MaxFragmentDepth
+	}
+
+	return &Analyzer{types: objectTypes, maxAssumedListSize: maxAssumedListSize, maxFragmentDepth: maxFragmentDepth}, nil
+}
+
+// Analyze traverses query and returns its complexity, resolving field costs
+// from the schema passed to NewAnalyzer. Fields with no @cost or @complexity
+// directive fall back to the same defaults GetQueryComplexity uses.
+func (a *Analyzer) Analyze(queryString string, variables map[string]interface{}) (int, error) {
+	complexity := 0
+
+	executableDefinition, err := query.Parse(queryString)
+	if err != nil {
+		return 0, err
+	}
+
+	fragUsed := make(map[string]types.SelectionSet)
+	for _, f := range executableDefinition.Fragments {
+		fragUsed[f.Name.Name] = f.Selections
+	}
+
+	state := &queryState{
+		variables:        variables,
+		fragUsed:         fragUsed,
+		visiting:         make(map[string]bool),
+		fragComplexity:   make(map[string]int),
+		maxFragmentDepth: a.maxFragmentDepth,
+	}
+
+	for _, op := range executableDefinition.Operations {
+		base := 0
+		if op.Type == query.Mutation {
+			base = mutationComplexity
+		}
+		c, err := a.calculateTypeComplexity(rootTypeNames[op.Type], op.Selections, state)
+		if err != nil {
+			return 0, err
+		}
+		complexity += base + c
+	}
+
+	return complexity, nil
+}
+
+// calculateTypeComplexity mirrors calculateSelectionComplexity but resolves
+// field overrides from the schema by "TypeName.fieldName" and, when a field
+// declares @complexity(multipliers: [...]), scales its subtree cost by the
+// named arguments instead of the hard-coded first/last connection heuristic.
+func (a *Analyzer) calculateTypeComplexity(typeName string, sels []types.Selection, state *queryState) (int, error) {
+	complexity := 0
+	ot := a.types[typeName]
+
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *types.Field:
+			fieldName := sel.Name.Name
+			if fieldName == "pageInfo" {
+				continue
+			}
+			if fieldName == "edges" {
+				// edges is itself declared on the connection type as
+				// "edges: [SomeEdge]"; recurse using that edge type so
+				// "node" (and anything else on the edge) resolves against
+				// the edge's own fields instead of the connection's.
+				edgeType := typeName
+				if ot != nil {
+					if edgesField := ot.Fields["edges"]; edgesField != nil {
+						edgeType = edgesField.ReturnType
+					}
+				}
+				c, err := a.calculateTypeComplexity(edgeType, sel.SelectionSet, state)
+				if err != nil {
+					return 0, err
+				}
+				complexity += c
+				continue
+			}
+
+			var field *common.SchemaField
+			if ot != nil {
+				field = ot.Fields[fieldName]
+			}
+			cost, multipliers, hasCost, err := fieldCost(field)
+			if err != nil {
+				return 0, err
+			}
+
+			childComplexity := 0
+			if sel.SelectionSet != nil {
+				childType := ""
+				if field != nil {
+					childType = field.ReturnType
+				}
+				c, err := a.calculateTypeComplexity(childType, sel.SelectionSet, state)
+				if err != nil {
+					return 0, err
+				}
+				childComplexity = c
+			}
+
+			switch {
+			case len(multipliers) > 0:
+				factor, err := resolveMultiplier(sel.Arguments, multipliers, field, common.VariableValues(state.variables), a.maxAssumedListSize)
+				if err != nil {
+					return 0, err
+				}
+				complexity += (factor * childComplexity) + cost
+			case isConnection(sel.Arguments):
+				itemCount, err := getConnectionNodeCount(sel.Arguments, state.variables)
+				if err != nil {
+					return 0, err
+				}
+				complexity += (itemCount * childComplexity) + connectionComplexity
+			case sel.SelectionSet != nil:
+				complexity += childComplexity + cost
+			case hasCost:
+				// A leaf field (no children, no connection args) still owes
+				// its declared @cost/@complexity value; only an undecorated
+				// leaf falls back to contributing nothing.
+				complexity += cost
+			}
+		case *types.FragmentSpread:
+			fieldName := sel.Name.Name
+			fragVal, ok := state.fragUsed[fieldName]
+			if !ok {
+				continue
+			}
+			// cacheKey scopes memoization to the type it was evaluated
+			// against, not just the fragment's name: the same fragment
+			// spread under two different concrete types (e.g. "dog" and
+			// "cat" both spreading a shared fragment) can resolve each
+			// field's @cost differently, so a "cat" spread must not reuse
+			// the cost computed for a "dog" spread of the same fragment.
+			cacheKey := fmt.Sprintf("%s.%s", typeName, fieldName)
+			if cached, ok := state.fragComplexity[cacheKey]; ok {
+				complexity += cached
+				continue
+			}
+			if state.visiting[fieldName] {
+				return 0, fragmentCycle(fieldName, sel.Loc)
+			}
+			state.fragDepth++
+			if state.maxFragmentDepth > 0 && state.fragDepth > state.maxFragmentDepth {
+				state.fragDepth--
+				return 0, fragmentDepthExceeded(fieldName, sel.Loc)
+			}
+			state.visiting[fieldName] = true
+			c, err := a.calculateTypeComplexity(typeName, fragVal, state)
+			delete(state.visiting, fieldName)
+			state.fragDepth--
+			if err != nil {
+				return 0, err
+			}
+			state.fragComplexity[cacheKey] = c
+			complexity += c
+		case *types.InlineFragment:
+			c, err := a.calculateTypeComplexity(typeName, sel.Fragment.Selections, state)
+			if err != nil {
+				return 0, err
+			}
+			complexity += c
+		}
+	}
+
+	return complexity, nil
+}
+
+// fieldCost resolves the @cost or @complexity directive declared on field
+// via common.CostDirective, falling back to the plain object field cost
+// when field is unknown or declares neither directive. Multipliers is only
+// returned when the directive's useMultipliers behavior is on, so callers
+// can keep treating a non-empty multipliers slice as "apply it". hasCost
+// reports whether field declared an explicit directive, as opposed to cost
+// merely being the objectComplexity default: a leaf field with no children
+// still owes a cost its schema declared, but an undecorated leaf field owes
+// nothing, so callers need to tell the two apart.
+func fieldCost(field *common.SchemaField) (cost int, multipliers []string, hasCost bool, err error) {
+	if field == nil {
+		return objectComplexity, nil, false, nil
+	}
+	cv, err := common.CostDirective(field)
+	if err != nil {
+		return 0, nil, false, err
+	}
+	if cv == nil {
+		return objectComplexity, nil, false, nil
+	}
+	if cv.UseMultipliers {
+		return cv.Complexity, cv.Multipliers, true, nil
+	}
+	return cv.Complexity, nil, true, nil
+}
+
+// resolveMultiplier multiplies together the integer value of each named
+// argument in multipliers, resolving a $variable reference against
+// variables (falling back to the argument's schema default, then to
+// maxAssumed) and defaulting an argument absent from the query to 1.
+func resolveMultiplier(args types.ArgumentList, multipliers []string, field *common.SchemaField, variables common.VariableValues, maxAssumed int) (int, error) {
+	factor := 1
+	for _, name := range multipliers {
+		def := fieldArgDef(field, name)
+		for _, a := range args {
+			if a.Name.Name != name {
+				continue
+			}
+			v, err := common.ResolveIntArg(a.Value, def, variables, maxAssumed)
+			if err != nil {
+				return 0, err
+			}
+			factor *= v
+		}
+	}
+	return factor, nil
+}
+
+// fieldArgDef returns the InputValueDefinition for one of field's declared
+// arguments, so its schema default can back-fill an unresolved variable.
+func fieldArgDef(field *common.SchemaField, name string) *types.InputValueDefinition {
+	if field == nil {
+		return nil
+	}
+	for _, a := range field.Args {
+		if a.Name.Name == name {
+			return a
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package complexity_test
+
+import (
+	"testing"
+
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/stretchr/testify/assert"
+	complexity "gitlab.com/infor-cloud/martian-cloud/tharsis/graphql-query-complexity"
+)
+
+const costSchema = `
+type Group {
+	id: ID!
+	members(first: Int): MemberConnection @cost(complexity: 3, multipliers: ["first"], useMultipliers: true)
+	legacyMembers(batchSize: Int): MemberConnection @cost(complexity: 5, multipliers: ["batchSize"], useMultipliers: false)
+	ghost: String @cost(complexity: 5, multipliers: ["missing"], useMultipliers: true)
+	badType(name: String): String @cost(complexity: 1, multipliers: ["name"], useMultipliers: true)
+}
+
+type MemberConnection {
+	edges: [MemberEdge]
+}
+
+type MemberEdge {
+	node: Group
+}
+
+type Query {
+	group(fullPath: String): Group
+}
+`
+
+func TestAnalyzerAnalyze_CostDirective(t *testing.T) {
+	analyzer, err := complexity.NewAnalyzer(costSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		wantComplexity int
+	}{
+		{
+			name: "useMultipliers true scales cost by the named argument",
+			query: `query {
+				group(fullPath: "g") {
+					members(first: 4) {
+						edges {
+							node {
+								id
+							}
+						}
+					}
+				}
+			}`,
+			wantComplexity: 8,
+		},
+		{
+			name: "useMultipliers false ignores the declared multiplier",
+			query: `query {
+				group(fullPath: "g") {
+					legacyMembers(batchSize: 10) {
+						edges {
+							node {
+								id
+							}
+						}
+					}
+				}
+			}`,
+			wantComplexity: 7,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := analyzer.Analyze(test.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, test.wantComplexity, actual)
+		})
+	}
+}
+
+func TestAnalyzerAnalyze_CostDirectiveValidation(t *testing.T) {
+	analyzer, err := complexity.NewAnalyzer(costSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{
+			name:  "multiplier naming an argument the field doesn't declare",
+			query: `query { group(fullPath: "g") { ghost } }`,
+		},
+		{
+			name:  "multiplier naming a non-Int argument",
+			query: `query { group(fullPath: "g") { badType(name: "x") } }`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := analyzer.Analyze(test.query, nil)
+			var queryErr *gqlerrors.QueryError
+			if assert.ErrorAs(t, err, &queryErr) {
+				assert.NotEmpty(t, queryErr.Locations)
+			}
+		})
+	}
+}
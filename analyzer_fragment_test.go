@@ -0,0 +1,154 @@
+package complexity_test
+
+import (
+	"errors"
+	"testing"
+
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/stretchr/testify/assert"
+	complexity "gitlab.com/infor-cloud/martian-cloud/tharsis/graphql-query-complexity"
+)
+
+func TestAnalyzerAnalyze_FragmentCycle(t *testing.T) {
+	analyzer, err := complexity.NewAnalyzer(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := `{
+			group(fullPath: "colonies") {
+			  ...A
+			}
+		  }
+
+		  fragment A on Group {
+			...B
+		  }
+
+		  fragment B on Group {
+			...A
+		  }
+		  `
+
+	_, err = analyzer.Analyze(query, nil)
+
+	assert.True(t, errors.Is(err, complexity.ErrFragmentCycle))
+
+	var queryErr *gqlerrors.QueryError
+	if assert.ErrorAs(t, err, &queryErr) {
+		assert.Equal(t, "NoFragmentCycles", queryErr.Rule)
+		assert.NotEmpty(t, queryErr.Locations)
+	}
+}
+
+func TestAnalyzerAnalyze_MaxFragmentDepth(t *testing.T) {
+	analyzer, err := complexity.NewAnalyzerWithOptions(testSchema, &complexity.AnalyzerOptions{MaxFragmentDepth: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := `{
+			group(fullPath: "colonies") {
+			  ...a
+			}
+		  }
+
+		  fragment a on Group {
+			...b
+		  }
+
+		  fragment b on Group {
+			...c
+		  }
+
+		  fragment c on Group {
+			name
+		  }
+		  `
+
+	_, err = analyzer.Analyze(query, nil)
+
+	assert.True(t, errors.Is(err, complexity.ErrMaxFragmentDepth))
+
+	var queryErr *gqlerrors.QueryError
+	if assert.ErrorAs(t, err, &queryErr) {
+		assert.Equal(t, "MaxFragmentDepth", queryErr.Rule)
+		assert.NotEmpty(t, queryErr.Locations)
+	}
+}
+
+func TestAnalyzerAnalyze_SharedFragmentScopedByType(t *testing.T) {
+	schema := `
+	type Dog {
+		weight: Int @complexity(value: 1)
+	}
+
+	type Cat {
+		weight: Int @complexity(value: 99)
+	}
+
+	type Query {
+		dog: Dog
+		cat: Cat
+	}
+	`
+
+	analyzer, err := complexity.NewAnalyzer(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := `{
+			dog {
+			  ...weight
+			}
+			cat {
+			  ...weight
+			}
+		  }
+
+		  fragment weight on Dog {
+			weight
+		  }
+		  `
+
+	// The fragment is only declared "on Dog", but since this package doesn't
+	// model interfaces/unions, a spread resolves against whatever concrete
+	// type it was reached through; the cat's own @complexity(value: 99) for
+	// "weight" must not be shadowed by the cost already cached for the dog's
+	// spread of the same fragment.
+	actual, err := analyzer.Analyze(query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 102, actual)
+}
+
+func TestAnalyzerAnalyze_SharedFragmentIsWalkedOnce(t *testing.T) {
+	analyzer, err := complexity.NewAnalyzer(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := `{
+			a: group(fullPath: "a") {
+			  ...info
+			}
+			b: group(fullPath: "b") {
+			  ...info
+			}
+		  }
+
+		  fragment info on Group {
+			name
+		  }
+		  `
+
+	actual, err := analyzer.Analyze(query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each group spreads the same fragment; the fragment's cost is still
+	// counted once per spread, only the walk of its selection set is shared.
+	assert.Equal(t, 2, actual)
+}
@@ -0,0 +1,135 @@
+package complexity_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	complexity "gitlab.com/infor-cloud/martian-cloud/tharsis/graphql-query-complexity"
+)
+
+const testSchema = `
+type Group {
+	id: ID!
+	name: String!
+	parent: Group @complexity(value: 3)
+	decendentGroups(first: Int, last: Int): GroupConnection @complexity(value: 2, multipliers: ["first", "last"])
+}
+
+type GroupConnection {
+	pageInfo: PageInfo
+	edges: [GroupEdge]
+}
+
+type GroupEdge {
+	node: Group
+}
+
+type PageInfo {
+	hasNextPage: Boolean
+}
+
+type Query {
+	group(fullPath: String): Group
+	groups(first: Int, last: Int): GroupConnection @complexity(value: 2, multipliers: ["first", "last"])
+	heavyCalc: Int @complexity(value: 50)
+}
+`
+
+func TestAnalyzerAnalyze(t *testing.T) {
+	analyzer, err := complexity.NewAnalyzer(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		variables      map[string]interface{}
+		wantComplexity int
+		wantErrMessage string
+	}{
+		{
+			name: "field without directive falls back to object default",
+			query: `query {
+				group(fullPath: "colonies") {
+					name
+				}
+			}`,
+			wantComplexity: 1,
+		},
+		{
+			name: "field with declared value overrides the default",
+			query: `query {
+				group(fullPath: "colonies") {
+					parent {
+						name
+					}
+				}
+			}`,
+			wantComplexity: 4,
+		},
+		{
+			name: "connection field scaled by declared multipliers",
+			query: `query {
+				groups(first: 5) {
+					edges {
+						node {
+							id
+						}
+					}
+				}
+			}`,
+			wantComplexity: 7,
+		},
+		{
+			name: "same field name on two different types does not collide",
+			query: `query {
+				group(fullPath: "colonies") {
+					decendentGroups(first: 2) {
+						edges {
+							node {
+								id
+							}
+						}
+					}
+				}
+			}`,
+			wantComplexity: 5,
+		},
+		{
+			name: "leaf field with no children or connection args still costs its declared value",
+			query: `query {
+				heavyCalc
+			}`,
+			wantComplexity: 50,
+		},
+		{
+			name: "directive on a field reached through edges/node is not ignored",
+			query: `query {
+				groups(first: 2) {
+					edges {
+						node {
+							parent {
+								id
+							}
+						}
+					}
+				}
+			}`,
+			wantComplexity: 10,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := analyzer.Analyze(test.query, test.variables)
+			if test.wantErrMessage != "" {
+				assert.EqualError(t, err, test.wantErrMessage)
+			} else if err != nil {
+				t.Fatal(err)
+			} else {
+				assert.Equal(t, test.wantComplexity, actual)
+			}
+		})
+	}
+}
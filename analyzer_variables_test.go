@@ -0,0 +1,140 @@
+package complexity_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	complexity "gitlab.com/infor-cloud/martian-cloud/tharsis/graphql-query-complexity"
+)
+
+const variableSchema = `
+type Group {
+	id: ID!
+	members(first: Int = 5): MemberConnection @cost(complexity: 3, multipliers: ["first"], useMultipliers: true)
+}
+
+type MemberConnection {
+	edges: [MemberEdge]
+}
+
+type MemberEdge {
+	node: Group
+}
+
+type Query {
+	group(fullPath: String): Group
+}
+`
+
+func TestAnalyzerAnalyze_VariableMultiplier(t *testing.T) {
+	analyzer, err := complexity.NewAnalyzer(variableSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		variables      map[string]interface{}
+		wantComplexity int
+	}{
+		{
+			name: "multiplier resolved from supplied variable",
+			query: `query($count: Int) {
+				group(fullPath: "g") {
+					members(first: $count) {
+						edges {
+							node {
+								id
+							}
+						}
+					}
+				}
+			}`,
+			variables:      map[string]interface{}{"count": float64(4)},
+			wantComplexity: 8,
+		},
+		{
+			name: "missing variable falls back to the argument's schema default",
+			query: `query($count: Int) {
+				group(fullPath: "g") {
+					members(first: $count) {
+						edges {
+							node {
+								id
+							}
+						}
+					}
+				}
+			}`,
+			wantComplexity: 9,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := analyzer.Analyze(test.query, test.variables)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, test.wantComplexity, actual)
+		})
+	}
+}
+
+func TestAnalyzerAnalyze_VariableMultiplierMaxAssumed(t *testing.T) {
+	schemaNoDefault := `
+	type Group {
+		id: ID!
+		members(first: Int): MemberConnection @cost(complexity: 3, multipliers: ["first"], useMultipliers: true)
+	}
+
+	type MemberConnection {
+		edges: [MemberEdge]
+	}
+
+	type MemberEdge {
+		node: Group
+	}
+
+	type Query {
+		group(fullPath: String): Group
+	}
+	`
+
+	query := `query($count: Int) {
+		group(fullPath: "g") {
+			members(first: $count) {
+				edges {
+					node {
+						id
+					}
+				}
+			}
+		}
+	}`
+
+	t.Run("missing variable with no default assumes the package default list size", func(t *testing.T) {
+		analyzer, err := complexity.NewAnalyzer(schemaNoDefault)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := analyzer.Analyze(query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 100*1+3+1, actual)
+	})
+
+	t.Run("missing variable with no default assumes a configured list size", func(t *testing.T) {
+		analyzer, err := complexity.NewAnalyzerWithOptions(schemaNoDefault, &complexity.AnalyzerOptions{MaxAssumedListSize: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := analyzer.Analyze(query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 10*1+3+1, actual)
+	})
+}
@@ -7,89 +7,110 @@ import (
 	"strconv"
 
 	"github.com/graph-gophers/graphql-go/types"
-	"gitlab.com/infor-cloud/martian-cloud/tharsis/graphql-query-complexity/internal/query"
 )
 
 // connectionComplexity sets complexity value for fields that are type connection
 // ojectComplexity sets complexity value for field that are type object
 // mutationComplexity sets complexity for mutation operation
+// subscriptionComplexity sets the default base complexity for a subscription
+// operation; it is higher than mutationComplexity because a subscription is
+// long-lived and re-evaluates its selection on every event
 const (
-	connectionComplexity = 2
-	objectComplexity     = 1
-	mutationComplexity   = 10
+	connectionComplexity   = 2
+	objectComplexity       = 1
+	mutationComplexity     = 10
+	subscriptionComplexity = 15
 )
 
+// defaultSubscriptionConnectionMultiplier is used in place of
+// Options.SubscriptionConnectionMultiplier when it is left unset (zero),
+// meaning connection fields inside a subscription payload are not scaled.
+const defaultSubscriptionConnectionMultiplier = 1
+
 type queryState struct {
 	variables      map[string]interface{}
 	fieldOverrides map[string]int
 	fragUsed       map[string]types.SelectionSet
+	limits         *Limits
+	aliasCount     *int
+	// subscriptionConnMultiplier scales the complexity of connection fields
+	// selected directly in a subscription's payload; see
+	// Options.SubscriptionConnectionMultiplier.
+	subscriptionConnMultiplier int
+	// visiting tracks the fragments currently being walked, so a cycle of
+	// fragment spreads (fragment A spreads B, B spreads A) is reported as
+	// ErrFragmentCycle instead of recursing forever.
+	visiting map[string]bool
+	// fragComplexity memoizes the computed cost of each fragment, keyed by
+	// its name plus the depth (GetQueryComplexity) or type name (Analyzer)
+	// it was walked at, so a fragment spread in several sibling selections
+	// is walked once.
+	fragComplexity map[string]int
+	// fragDepth counts the fragment spreads currently nested inside one
+	// another (not the overall selection depth); maxFragmentDepth bounds it
+	// so a long chain of spreads can't exhaust the stack even if it isn't a
+	// cycle. Only Analyzer sets maxFragmentDepth; zero leaves it unenforced.
+	fragDepth        int
+	maxFragmentDepth int
 }
 
 // GetQueryComplexity traverses queries and calculates complexity
 func GetQueryComplexity(queryString string, variables map[string]interface{}, fieldOverrides map[string]int) (int, error) {
-	complexity := 0
-	// fragUsed initializes a map with a key: fragment name value: types.Fragment
-	fragUsed := make(map[string]types.SelectionSet)
-	// parse and lex the provided query string
-	executableDefinition, err := query.Parse(queryString)
-	if err != nil {
-		return 0, err
-	}
-	// creates map of fragments with name as key
-	for _, f := range executableDefinition.Fragments {
-		fragUsed[f.Name.Name] = f.Selections
-	}
-
-	state := &queryState{
-		variables:      variables,
-		fieldOverrides: fieldOverrides,
-		fragUsed:       fragUsed,
-	}
+	return GetQueryComplexityWithLimits(queryString, variables, fieldOverrides, nil)
+}
 
-	// for each operation calculate complexity based on operation type and field types
-	for _, op := range executableDefinition.Operations {
-		switch op.Type {
-		case query.Query:
-			c, err := calculateSelectionComplexity(op.Selections, state)
-			if err != nil {
-				return 0, err
-			}
-			complexity += c
-		case query.Mutation:
-			c, err := calculateMutationComplexity(op.Selections, state)
-			if err != nil {
-				return 0, err
-			}
-			complexity += c
-		case query.Subscription:
-			// including incase sub cal is needed in the future
-			// return complexity
-		}
-	}
-	return complexity, nil
+// GetQueryComplexityWithLimits behaves like GetQueryComplexity, but also
+// enforces limits (if non-nil) while the query is walked, returning a
+// *LimitExceededError as soon as a threshold is crossed instead of
+// computing the full complexity of a pathological document.
+func GetQueryComplexityWithLimits(queryString string, variables map[string]interface{}, fieldOverrides map[string]int, limits *Limits) (int, error) {
+	return GetQueryComplexityWithOptions(queryString, variables, &Options{
+		FieldOverrides: fieldOverrides,
+		Limits:         limits,
+	})
 }
 
-// calculateSelectionComplexity calculates and returns complexity for queries
-func calculateSelectionComplexity(sels []types.Selection, state *queryState) (int, error) {
+// calculateSelectionComplexity calculates and returns complexity for queries.
+// depth is the current selection-set nesting level (the operation's root
+// selection set is depth 1) and fieldPath is the dotted path of fields from
+// the operation root down to sels, both used to report which field tripped
+// a configured Limits threshold.
+func calculateSelectionComplexity(sels []types.Selection, state *queryState, depth int, fieldPath string) (int, error) {
 	complexity := 0
 
+	if state.limits != nil && state.limits.MaxBreadth > 0 && len(sels) > state.limits.MaxBreadth {
+		return 0, limitExceeded("breadth", fieldPath, complexity)
+	}
+
 	for _, sel := range sels {
 		switch sel := sel.(type) {
 		case *types.Field:
 			fieldName := sel.Name.Name
+			childPath := joinFieldPath(fieldPath, fieldName)
+
+			if sel.Alias.Name != fieldName {
+				*state.aliasCount++
+				if state.limits != nil && state.limits.MaxAliases > 0 && *state.aliasCount > state.limits.MaxAliases {
+					return 0, limitExceeded("aliases", childPath, complexity)
+				}
+			}
+
 			if isOverride(fieldName, state.fieldOverrides) {
 				overrideVal := state.fieldOverrides[fieldName]
 				complexity += overrideVal
 			} else if fieldName == "pageInfo" {
 				continue
 			} else if fieldName == "edges" {
-				c, err := calculateSelectionComplexity(sel.SelectionSet, state)
+				c, err := calculateSelectionComplexity(sel.SelectionSet, state, depth, childPath)
 				if err != nil {
 					return 0, err
 				}
 				complexity += c
 			} else if isConnection(sel.Arguments) {
-				c, err := calculateSelectionComplexity(sel.SelectionSet, state)
+				if err := checkDepth(state, childPath, depth+1, complexity); err != nil {
+					return 0, err
+				}
+				c, err := calculateSelectionComplexity(sel.SelectionSet, state, depth+1, childPath)
 				if err != nil {
 					return 0, err
 				}
@@ -100,24 +121,49 @@ func calculateSelectionComplexity(sels []types.Selection, state *queryState) (in
 				complexity += (itemCount * c) + connectionComplexity
 			} else {
 				if sel.SelectionSet != nil {
-					c, err := calculateSelectionComplexity(sel.SelectionSet, state)
+					if err := checkDepth(state, childPath, depth+1, complexity); err != nil {
+						return 0, err
+					}
+					c, err := calculateSelectionComplexity(sel.SelectionSet, state, depth+1, childPath)
 					if err != nil {
 						return 0, err
 					}
 					complexity += (c + objectComplexity)
 				}
 			}
+
+			if err := checkComplexity(state, childPath, complexity); err != nil {
+				return 0, err
+			}
 		case *types.FragmentSpread:
 			fieldName := sel.Name.Name
-			if fragVal, ok := state.fragUsed[fieldName]; ok {
-				c, err := calculateSelectionComplexity(fragVal, state)
-				if err != nil {
-					return 0, err
-				}
-				complexity += c
+			fragVal, ok := state.fragUsed[fieldName]
+			if !ok {
+				break
 			}
+			// cacheKey scopes memoization to the depth a spread is evaluated
+			// at, not just the fragment's name: a fragment spread shallowly
+			// elsewhere in the document must not let a deeper spread of the
+			// same fragment skip the depth/breadth/complexity checks that
+			// walking it for real at that depth would trip.
+			cacheKey := fmt.Sprintf("%s@%d", fieldName, depth)
+			if cached, ok := state.fragComplexity[cacheKey]; ok {
+				complexity += cached
+				break
+			}
+			if state.visiting[fieldName] {
+				return 0, fragmentCycle(fieldName, sel.Loc)
+			}
+			state.visiting[fieldName] = true
+			c, err := calculateSelectionComplexity(fragVal, state, depth, fieldPath)
+			delete(state.visiting, fieldName)
+			if err != nil {
+				return 0, err
+			}
+			state.fragComplexity[cacheKey] = c
+			complexity += c
 		case *types.InlineFragment:
-			c, err := calculateSelectionComplexity(sel.Fragment.Selections, state)
+			c, err := calculateSelectionComplexity(sel.Fragment.Selections, state, depth, fieldPath)
 			if err != nil {
 				return 0, err
 			}
@@ -127,6 +173,27 @@ func calculateSelectionComplexity(sels []types.Selection, state *queryState) (in
 	return complexity, nil
 }
 
+// checkDepth returns a LimitExceededError if descending to nextDepth would
+// cross state.limits.MaxDepth, reporting complexity as the partial
+// complexity accumulated so far.
+func checkDepth(state *queryState, fieldPath string, nextDepth int, complexity int) error {
+	if state.limits == nil || state.limits.MaxDepth == 0 {
+		return nil
+	}
+	if nextDepth > state.limits.MaxDepth {
+		return limitExceeded("depth", fieldPath, complexity)
+	}
+	return nil
+}
+
+// joinFieldPath appends fieldName to the dotted path of fields built up so far.
+func joinFieldPath(fieldPath, fieldName string) string {
+	if fieldPath == "" {
+		return fieldName
+	}
+	return fieldPath + "." + fieldName
+}
+
 // calculateMutationComplexity calculates complexity recursively for mutations
 func calculateMutationComplexity(sels []types.Selection, state *queryState) (int, error) {
 	complexity := mutationComplexity
@@ -137,14 +204,62 @@ func calculateMutationComplexity(sels []types.Selection, state *queryState) (int
 			for _, x := range sel.SelectionSet {
 				switch y := x.(type) {
 				case *types.Field:
-					c, err := calculateSelectionComplexity(y.SelectionSet, state)
+					// y's own selection set is nested under the root field's,
+					// so it sits at depth 3.
+					yPath := joinFieldPath(sel.Name.Name, y.Name.Name)
+					if y.SelectionSet != nil {
+						if err := checkDepth(state, yPath, 3, complexity); err != nil {
+							return 0, err
+						}
+					}
+					c, err := calculateSelectionComplexity(y.SelectionSet, state, 3, yPath)
+					if err != nil {
+						return 0, err
+					}
+					complexity += c
+				case *types.FragmentSpread:
+					if fragVal, ok := state.fragUsed[y.Name.Name]; ok {
+						c, err := calculateSelectionComplexity(fragVal, state, 2, sel.Name.Name)
+						if err != nil {
+							return 0, err
+						}
+						complexity += c
+					}
+				}
+			}
+		}
+	}
+	return complexity, nil
+}
+
+// calculateSubscriptionComplexity calculates complexity for a subscription
+// operation. It walks the root selection like calculateMutationComplexity,
+// but starts from baseCost instead of the fixed mutationComplexity, and
+// scales each connection field selected directly in the subscription
+// payload (a child of the root field) by state.subscriptionConnMultiplier
+// to account for it being re-evaluated on every event the subscription
+// delivers. Connection fields nested deeper in the payload, or reached
+// through a fragment spread, are not scaled.
+func calculateSubscriptionComplexity(sels []types.Selection, state *queryState, baseCost int) (int, error) {
+	complexity := baseCost
+
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *types.Field:
+			for _, x := range sel.SelectionSet {
+				switch y := x.(type) {
+				case *types.Field:
+					c, err := calculateSelectionComplexity([]types.Selection{y}, state, 2, sel.Name.Name)
 					if err != nil {
 						return 0, err
 					}
+					if isConnection(y.Arguments) {
+						c *= state.subscriptionConnMultiplier
+					}
 					complexity += c
 				case *types.FragmentSpread:
 					if fragVal, ok := state.fragUsed[y.Name.Name]; ok {
-						c, err := calculateSelectionComplexity(fragVal, state)
+						c, err := calculateSelectionComplexity(fragVal, state, 2, sel.Name.Name)
 						if err != nil {
 							return 0, err
 						}
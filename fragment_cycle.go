@@ -0,0 +1,51 @@
+package complexity
+
+import (
+	"errors"
+	"fmt"
+
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+// ErrFragmentCycle is returned (wrapped, so callers can match it with
+// errors.Is) when a document's fragments spread each other in a cycle, e.g.
+// "fragment A on T { ...B }" spreading back into "fragment B on T { ...A }".
+// Spec-compliant validators reject such documents before execution, but this
+// package may be asked to score a query before the server's own validator
+// has had a chance to run, so it must not infinite-loop on one.
+var ErrFragmentCycle = errors.New("fragment cycle detected")
+
+// fragmentCycle builds a *gqlerrors.QueryError reporting the cycle closed by
+// spreading name again. Rule is set to "NoFragmentCycles", the name
+// graphql-go's own validator uses for this check, and Locations to loc, the
+// position of the spread that closed the cycle, so callers get the same
+// shape of error a pre-execution validator would have raised. The error
+// still wraps ErrFragmentCycle, so existing errors.Is(err, ErrFragmentCycle)
+// checks keep working.
+func fragmentCycle(name string, loc gqlerrors.Location) error {
+	return &gqlerrors.QueryError{
+		Message:   fmt.Sprintf("fragment cycle detected: %q", name),
+		Locations: []gqlerrors.Location{loc},
+		Rule:      "NoFragmentCycles",
+		Err:       ErrFragmentCycle,
+	}
+}
+
+// ErrMaxFragmentDepth is returned (wrapped, so callers can match it with
+// errors.Is) when a chain of fragment spreads nests deeper than a caller's
+// configured MaxFragmentDepth. It backstops fragmentCycle's own cycle check:
+// a document that isn't a cycle but spreads fragments arbitrarily deep could
+// otherwise still exhaust the stack.
+var ErrMaxFragmentDepth = errors.New("fragment nesting exceeds max depth")
+
+// fragmentDepthExceeded builds a *gqlerrors.QueryError reporting that
+// spreading name would nest fragments deeper than the configured
+// MaxFragmentDepth, positioned at loc, the spread that crossed the limit.
+func fragmentDepthExceeded(name string, loc gqlerrors.Location) error {
+	return &gqlerrors.QueryError{
+		Message:   fmt.Sprintf("fragment %q exceeds max fragment depth", name),
+		Locations: []gqlerrors.Location{loc},
+		Rule:      "MaxFragmentDepth",
+		Err:       ErrMaxFragmentDepth,
+	}
+}
@@ -0,0 +1,85 @@
+package complexity_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	complexity "gitlab.com/infor-cloud/martian-cloud/tharsis/graphql-query-complexity"
+)
+
+func TestGetQueryComplexity_FragmentCycle(t *testing.T) {
+	query := `{
+			group(fullPath: "colonies") {
+			  ...A
+			}
+		  }
+
+		  fragment A on Group {
+			...B
+		  }
+
+		  fragment B on Group {
+			...A
+		  }
+		  `
+
+	_, err := complexity.GetQueryComplexity(query, nil, nil)
+
+	assert.True(t, errors.Is(err, complexity.ErrFragmentCycle))
+}
+
+func TestGetQueryComplexity_SharedFragmentIsWalkedOnce(t *testing.T) {
+	query := `{
+			a: group(fullPath: "a") {
+			  ...info
+			}
+			b: group(fullPath: "b") {
+			  ...info
+			}
+		  }
+
+		  fragment info on Group {
+			id
+			name
+		  }
+		  `
+
+	actual, err := complexity.GetQueryComplexity(query, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each group spreads the same fragment; the fragment's cost is still
+	// counted once per spread, only the walk of its selection set is shared.
+	assert.Equal(t, 2, actual)
+}
+
+func TestGetQueryComplexity_SharedFragmentDepthCheckedPerSpread(t *testing.T) {
+	query := `{
+			a: group(fullPath: "a") {
+			  ...frag
+			}
+			b: group(fullPath: "b") {
+			  parent {
+				...frag
+			  }
+			}
+		  }
+
+		  fragment frag on Group {
+			parent {
+			  id
+			}
+		  }
+		  `
+
+	// "a" spreads frag at depth 2 (within MaxDepth); priming the fragment's
+	// complexity cache here must not let "b"'s spread of the same fragment
+	// at depth 3 skip its own depth check, which would cross MaxDepth.
+	_, err := complexity.GetQueryComplexityWithLimits(query, nil, nil, &complexity.Limits{MaxDepth: 3})
+
+	var limitErr *complexity.LimitExceededError
+	if assert.ErrorAs(t, err, &limitErr) {
+		assert.Equal(t, "depth", limitErr.Limit)
+	}
+}
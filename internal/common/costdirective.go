@@ -0,0 +1,143 @@
+package common
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/types"
+)
+
+// CostDirectiveValue is the cost declared on a field via @cost or
+// @complexity, resolved to a single shape so the complexity calculator
+// doesn't need to know which directive spelling the SDL author used.
+type CostDirectiveValue struct {
+	// Complexity is the field's own cost before any multiplier is applied.
+	Complexity int
+	// Multipliers names sibling arguments whose integer value scales
+	// Complexity; an argument absent from the query defaults to 1.
+	Multipliers []string
+	// UseMultipliers reports whether Multipliers should actually be applied.
+	// @cost spells this out via its useMultipliers argument; @complexity
+	// applies its multipliers whenever any are declared.
+	UseMultipliers bool
+}
+
+// CostDirective extracts the @cost or @complexity directive declared on
+// field, if either is present, recognizing:
+//
+//	@cost(complexity: Int, multipliers: [String!], useMultipliers: Boolean)
+//	@complexity(value: Int, multipliers: [String!])
+//
+// Every name in "multipliers" must reference one of field's own Int
+// arguments; CostDirective returns a *errors.QueryError otherwise,
+// positioned at the directive's name when the multiplier names no argument
+// of field, or at the argument's definition when that argument isn't an
+// Int. CostDirective returns a nil value and a nil
+// error when field declares neither directive.
+func CostDirective(field *SchemaField) (*CostDirectiveValue, error) {
+	for _, d := range field.Directives {
+		switch d.Name.Name {
+		case "cost":
+			return parseCostDirective(field, d)
+		case "complexity":
+			return parseComplexityDirective(field, d)
+		}
+	}
+	return nil, nil
+}
+
+func parseCostDirective(field *SchemaField, d *types.Directive) (*CostDirectiveValue, error) {
+	v := &CostDirectiveValue{}
+	for _, arg := range d.Arguments {
+		switch arg.Name.Name {
+		case "complexity":
+			v.Complexity = intLiteralValue(arg.Value)
+		case "multipliers":
+			v.Multipliers = stringListLiteralValue(arg.Value)
+		case "useMultipliers":
+			v.UseMultipliers = boolLiteralValue(arg.Value)
+		}
+	}
+	if err := validateMultipliers(field, d, v.Multipliers); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func parseComplexityDirective(field *SchemaField, d *types.Directive) (*CostDirectiveValue, error) {
+	v := &CostDirectiveValue{}
+	for _, arg := range d.Arguments {
+		switch arg.Name.Name {
+		case "value":
+			v.Complexity = intLiteralValue(arg.Value)
+		case "multipliers":
+			v.Multipliers = stringListLiteralValue(arg.Value)
+		}
+	}
+	v.UseMultipliers = len(v.Multipliers) > 0
+	if err := validateMultipliers(field, d, v.Multipliers); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// validateMultipliers rejects a multiplier name that isn't declared as one
+// of field's arguments, or that is but isn't typed Int.
+func validateMultipliers(field *SchemaField, d *types.Directive, multipliers []string) error {
+	for _, name := range multipliers {
+		arg := findFieldArg(field, name)
+		if arg == nil {
+			err := errors.Errorf("%s multiplier %q is not an argument of field %q", d.Name.Name, name, field.Name)
+			err.Locations = []errors.Location{d.Name.Loc}
+			return err
+		}
+		if bareTypeName(arg.Type) != "Int" {
+			err := errors.Errorf("%s multiplier %q must reference an Int argument of field %q, got %q", d.Name.Name, name, field.Name, bareTypeName(arg.Type))
+			err.Locations = []errors.Location{arg.Loc}
+			return err
+		}
+	}
+	return nil
+}
+
+func findFieldArg(field *SchemaField, name string) *types.InputValueDefinition {
+	for _, a := range field.Args {
+		if a.Name.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+func intLiteralValue(v types.Value) int {
+	p, ok := v.(*types.PrimitiveValue)
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.Atoi(p.String())
+	return n
+}
+
+func boolLiteralValue(v types.Value) bool {
+	p, ok := v.(*types.PrimitiveValue)
+	if !ok {
+		return false
+	}
+	b, _ := strconv.ParseBool(p.String())
+	return b
+}
+
+func stringListLiteralValue(v types.Value) []string {
+	list, ok := v.(*types.ListValue)
+	if !ok {
+		return nil
+	}
+	var out []string
+	for _, item := range list.Values {
+		if p, ok := item.(*types.PrimitiveValue); ok {
+			out = append(out, strings.Trim(p.String(), `"`))
+		}
+	}
+	return out
+}
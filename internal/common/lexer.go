@@ -92,6 +92,15 @@ func (l *Lexer) Peek() rune {
 	return l.next
 }
 
+// PeekIdent returns the text of the next token without consuming it, along
+// with whether the next token is in fact an identifier.
+func (l *Lexer) PeekIdent() (string, bool) {
+	if l.next != scanner.Ident {
+		return "", false
+	}
+	return l.sc.TokenText(), true
+}
+
 // ConsumeWhitespace consumes whitespace and tokens equivalent to whitespace (e.g. commas and comments).
 //
 // Consumed comment characters will build the description for the next type or field encountered.
@@ -0,0 +1,147 @@
+package common
+
+import (
+	"text/scanner"
+
+	"github.com/graph-gophers/graphql-go/types"
+)
+
+// SchemaField is a field definition parsed from a `type` block, reduced to
+// only what the complexity analyzer needs: the field's return type, so a
+// nested selection set can be resolved against the right object type, its
+// declared arguments, so a @cost/@complexity multiplier can be checked
+// against them, and any directives declared on it (e.g. @complexity).
+type SchemaField struct {
+	Name       string
+	ReturnType string // bare named type, with List/NonNull wrappers stripped
+	Args       []*types.InputValueDefinition
+	Directives types.DirectiveList
+}
+
+// ObjectType is a parsed `type Name { ... }` definition.
+type ObjectType struct {
+	Name   string
+	Fields map[string]*SchemaField
+}
+
+// ParseSchema parses schemaSDL and returns every object type it declares,
+// keyed by name. Named non-object definitions (scalar, enum, input,
+// interface, union) are skipped; they're consumed just enough to find the
+// next definition. A `schema { ... }` block or a `directive @name ...`
+// definition is not skipped: ParseSchema returns a syntax error for it.
+func ParseSchema(schemaSDL string) (map[string]*ObjectType, error) {
+	l := NewLexer(schemaSDL, false)
+	objectTypes := make(map[string]*ObjectType)
+
+	err := l.CatchSyntaxError(func() {
+		l.ConsumeWhitespace()
+		for l.Peek() != scanner.EOF {
+			parseSchemaDefinition(l, objectTypes)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return objectTypes, nil
+}
+
+func parseSchemaDefinition(l *Lexer, out map[string]*ObjectType) {
+	keyword := l.ConsumeIdent()
+	if keyword != "type" {
+		skipDefinition(l)
+		return
+	}
+
+	ot := &ObjectType{Name: l.ConsumeIdent(), Fields: make(map[string]*SchemaField)}
+
+	if ident, ok := l.PeekIdent(); ok && ident == "implements" {
+		l.ConsumeKeyword("implements")
+		l.ConsumeIdent()
+		for l.Peek() == '&' {
+			l.ConsumeToken('&')
+			l.ConsumeIdent()
+		}
+	}
+	ParseDirectives(l)
+
+	l.ConsumeToken('{')
+	for l.Peek() != '}' {
+		f := parseSchemaField(l)
+		ot.Fields[f.Name] = f
+	}
+	l.ConsumeToken('}')
+
+	out[ot.Name] = ot
+}
+
+func parseSchemaField(l *Lexer) *SchemaField {
+	name := l.ConsumeIdent()
+	var args []*types.InputValueDefinition
+	if l.Peek() == '(' {
+		l.ConsumeToken('(')
+		for l.Peek() != ')' {
+			args = append(args, ParseInputValue(l))
+		}
+		l.ConsumeToken(')')
+	}
+	l.ConsumeToken(':')
+	t := ParseType(l)
+	directives := ParseDirectives(l)
+	return &SchemaField{Name: name, ReturnType: bareTypeName(t), Args: args, Directives: directives}
+}
+
+// bareTypeName strips List/NonNull wrappers down to the underlying named type.
+func bareTypeName(t types.Type) string {
+	switch t := t.(type) {
+	case *types.NonNull:
+		return bareTypeName(t.OfType)
+	case *types.List:
+		return bareTypeName(t.OfType)
+	case *types.TypeName:
+		return t.Name
+	default:
+		return ""
+	}
+}
+
+// skipDefinition consumes a named non-`type` top-level definition (scalar,
+// enum, input, interface, union) just far enough to reach the next
+// top-level definition, without needing to understand its grammar. It
+// expects a name right after the keyword, so a nameless `schema { ... }`
+// block or a `directive @name ...` definition raises a syntax error.
+func skipDefinition(l *Lexer) {
+	l.ConsumeIdent() // name
+
+	if l.Peek() == '=' { // union Name = A | B
+		l.ConsumeToken('=')
+		l.ConsumeIdent()
+		for l.Peek() == '|' {
+			l.ConsumeToken('|')
+			l.ConsumeIdent()
+		}
+		return
+	}
+
+	if l.Peek() != '{' {
+		return
+	}
+
+	depth := 0
+	for {
+		switch l.Peek() {
+		case scanner.EOF:
+			return
+		case '{':
+			depth++
+			l.ConsumeWhitespace()
+		case '}':
+			depth--
+			l.ConsumeWhitespace()
+			if depth == 0 {
+				return
+			}
+		default:
+			l.ConsumeWhitespace()
+		}
+	}
+}
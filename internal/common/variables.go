@@ -0,0 +1,57 @@
+package common
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/graph-gophers/graphql-go/types"
+)
+
+// VariableValues is the map of variable values supplied alongside a GraphQL
+// request, keyed by variable name (without the leading "$").
+type VariableValues map[string]any
+
+// ResolveIntArg resolves value to an integer for complexity calculation.
+// A literal int is returned as-is. A $variable reference is resolved from
+// variables; if variables doesn't supply it, def's schema-declared default
+// is used instead; if def has no default either, maxAssumed is returned so
+// an unbounded paginated field (e.g. `users(first: $count)` called with
+// neither a value nor a default for $count) is never silently treated as
+// free.
+func ResolveIntArg(value types.Value, def *types.InputValueDefinition, variables VariableValues, maxAssumed int) (int, error) {
+	switch v := value.(type) {
+	case *types.PrimitiveValue:
+		return primitiveInt(v), nil
+	case *types.Variable:
+		if val, ok := variables[v.Name]; ok {
+			return anyToInt(val)
+		}
+		if def != nil && def.Default != nil {
+			if p, ok := def.Default.(*types.PrimitiveValue); ok {
+				return primitiveInt(p), nil
+			}
+		}
+		return maxAssumed, nil
+	}
+	return 0, nil
+}
+
+func primitiveInt(p *types.PrimitiveValue) int {
+	n, _ := strconv.Atoi(p.String())
+	return n
+}
+
+func anyToInt(val any) (int, error) {
+	switch n := val.(type) {
+	case json.Number:
+		i, err := n.Int64()
+		return int(i), err
+	case float64:
+		return int(n), nil
+	case float32:
+		return int(n), nil
+	case int:
+		return n, nil
+	}
+	return 0, nil
+}
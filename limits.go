@@ -0,0 +1,47 @@
+package complexity
+
+import "fmt"
+
+// Limits bounds how expensive a query is allowed to be before
+// GetQueryComplexityWithLimits rejects it, so pathological documents can be
+// turned away cheaply instead of having their full complexity computed.
+// A zero value for any field means that limit is not enforced.
+type Limits struct {
+	MaxDepth      int
+	MaxBreadth    int
+	MaxAliases    int
+	MaxComplexity int
+}
+
+// LimitExceededError is returned by GetQueryComplexityWithLimits as soon as
+// a configured Limits threshold is crossed.
+type LimitExceededError struct {
+	// Limit identifies which Limits field tripped: "depth", "breadth",
+	// "aliases", or "complexity".
+	Limit string
+	// FieldPath is the dotted path of fields from the operation root to the
+	// field being evaluated when the limit tripped.
+	FieldPath string
+	// Complexity is the complexity accumulated so far when the limit tripped.
+	Complexity int
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("query exceeds max %s limit at %q (complexity so far: %d)", e.Limit, e.FieldPath, e.Complexity)
+}
+
+func limitExceeded(limit, fieldPath string, complexity int) error {
+	return &LimitExceededError{Limit: limit, FieldPath: fieldPath, Complexity: complexity}
+}
+
+// checkComplexity returns a LimitExceededError if complexity has crossed
+// state.limits.MaxComplexity.
+func checkComplexity(state *queryState, fieldPath string, complexity int) error {
+	if state.limits == nil || state.limits.MaxComplexity == 0 {
+		return nil
+	}
+	if complexity > state.limits.MaxComplexity {
+		return limitExceeded("complexity", fieldPath, complexity)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package complexity_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	complexity "gitlab.com/infor-cloud/martian-cloud/tharsis/graphql-query-complexity"
+)
+
+func TestGetQueryComplexityWithLimits(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		limits         *complexity.Limits
+		wantComplexity int
+		wantErrLimit   string
+	}{
+		{
+			name: "under every limit succeeds",
+			query: `query{
+				groups(first: 5, sort: FULL_PATH_ASC) {
+				  edges {
+					node {
+					  id
+					}
+				  }
+				}
+			  }`,
+			limits:         &complexity.Limits{MaxDepth: 5, MaxBreadth: 5, MaxAliases: 5, MaxComplexity: 100},
+			wantComplexity: 7,
+		},
+		{
+			name: "depth limit trips before full complexity is computed",
+			query: `query{
+				me {
+					memberships(first:100) {
+						edges {
+						  node {
+							namespace {
+							  parent {
+								  id
+							  }
+							}
+						  }
+						}
+					}
+				}
+			  }`,
+			limits:       &complexity.Limits{MaxDepth: 2},
+			wantErrLimit: "depth",
+		},
+		{
+			name: "breadth limit trips on a wide selection set",
+			query: `query{
+				group(fullPath: "colonies") {
+					id
+					name
+					description
+				}
+			  }`,
+			limits:       &complexity.Limits{MaxBreadth: 2},
+			wantErrLimit: "breadth",
+		},
+		{
+			name: "alias limit trips when too many aliases are used",
+			query: `query{
+				a: group(fullPath: "a") { id }
+				b: group(fullPath: "b") { id }
+			  }`,
+			limits:       &complexity.Limits{MaxAliases: 1},
+			wantErrLimit: "aliases",
+		},
+		{
+			name: "complexity limit trips once the running total crosses it",
+			query: `query{
+				groups(first: 50) {
+				  edges {
+					node {
+					  id
+					}
+				  }
+				}
+			  }`,
+			limits:       &complexity.Limits{MaxComplexity: 10},
+			wantErrLimit: "complexity",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := complexity.GetQueryComplexityWithLimits(test.query, nil, nil, test.limits)
+			if test.wantErrLimit != "" {
+				var limitErr *complexity.LimitExceededError
+				if !assert.ErrorAs(t, err, &limitErr) {
+					return
+				}
+				assert.Equal(t, test.wantErrLimit, limitErr.Limit)
+			} else if err != nil {
+				t.Fatal(err)
+			} else {
+				assert.Equal(t, test.wantComplexity, actual)
+			}
+		})
+	}
+}
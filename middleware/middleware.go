@@ -0,0 +1,160 @@
+// Package middleware wires complexity into graphql-go and gqlgen HTTP
+// handlers, rejecting requests that exceed a configured budget before they
+// reach the resolvers.
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	complexity "gitlab.com/infor-cloud/martian-cloud/tharsis/graphql-query-complexity"
+)
+
+// BudgetStore lets callers enforce a per-caller complexity budget (e.g.
+// backed by Redis or an in-memory token bucket) in addition to the static
+// Options.MaxComplexity ceiling. Reserve should return an error if cost
+// cannot be drawn from the caller's remaining budget.
+type BudgetStore interface {
+	Reserve(ctx context.Context, cost int) error
+}
+
+// Options configures NewHTTPMiddleware and NewOperationMiddleware.
+type Options struct {
+	// FieldOverrides is passed straight through to GetQueryComplexityWithOptions.
+	FieldOverrides map[string]int
+	// Limits bounds depth/breadth/aliases/complexity while the query is walked.
+	Limits *complexity.Limits
+	// MaxComplexity rejects any request whose score exceeds it. Zero disables
+	// this check (Limits.MaxComplexity, if set, still applies during the walk).
+	MaxComplexity int
+	// BudgetStore, if set, is consulted after the static checks above pass.
+	BudgetStore BudgetStore
+}
+
+type contextKey string
+
+const complexityContextKey contextKey = "complexity"
+
+// ComplexityFromContext returns the complexity score NewHTTPMiddleware
+// computed for the current request, if any.
+func ComplexityFromContext(ctx context.Context) (int, bool) {
+	score, ok := ctx.Value(complexityContextKey).(int)
+	return score, ok
+}
+
+type requestBody struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+type graphQLError struct {
+	Message    string                 `json:"message"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+type graphQLErrorResponse struct {
+	Errors []graphQLError `json:"errors"`
+}
+
+// NewHTTPMiddleware returns middleware that decodes a standard GraphQL POST
+// body (query, variables, operationName), scores the operation it names with
+// GetQueryComplexityWithOptions, and either rejects the request with a
+// GraphQL-spec-compliant errors body or annotates the request context with
+// the computed score (retrievable via ComplexityFromContext) before calling
+// next.
+func NewHTTPMiddleware(opts Options) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			var req requestBody
+			if err := json.Unmarshal(body, &req); err != nil {
+				writeGraphQLError(w, "invalid GraphQL request body", 0, opts.MaxComplexity)
+				return
+			}
+
+			score, err := complexity.GetQueryComplexityWithOptions(req.Query, req.Variables, &complexity.Options{
+				FieldOverrides: opts.FieldOverrides,
+				Limits:         opts.Limits,
+				OperationName:  req.OperationName,
+			})
+			if err != nil {
+				writeGraphQLError(w, err.Error(), 0, opts.MaxComplexity)
+				return
+			}
+
+			if opts.MaxComplexity > 0 && score > opts.MaxComplexity {
+				writeGraphQLError(w, "query too complex", score, opts.MaxComplexity)
+				return
+			}
+
+			if opts.BudgetStore != nil {
+				if err := opts.BudgetStore.Reserve(r.Context(), score); err != nil {
+					writeGraphQLError(w, err.Error(), score, opts.MaxComplexity)
+					return
+				}
+			}
+
+			ctx := context.WithValue(r.Context(), complexityContextKey, score)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// NewOperationMiddleware returns a gqlgen graphql.OperationMiddleware that
+// applies the same checks as NewHTTPMiddleware to the operation already
+// parsed by gqlgen's handler.
+func NewOperationMiddleware(opts Options) graphql.OperationMiddleware {
+	return func(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+		oc := graphql.GetOperationContext(ctx)
+
+		score, err := complexity.GetQueryComplexityWithOptions(oc.RawQuery, oc.Variables, &complexity.Options{
+			FieldOverrides: opts.FieldOverrides,
+			Limits:         opts.Limits,
+			OperationName:  oc.OperationName,
+		})
+		if err != nil {
+			return graphql.OneShot(graphql.ErrorResponse(ctx, "%s", err.Error()))
+		}
+
+		if opts.MaxComplexity > 0 && score > opts.MaxComplexity {
+			return graphql.OneShot(graphql.ErrorResponse(ctx, "query too complex"))
+		}
+
+		if opts.BudgetStore != nil {
+			if err := opts.BudgetStore.Reserve(ctx, score); err != nil {
+				return graphql.OneShot(graphql.ErrorResponse(ctx, "%s", err.Error()))
+			}
+		}
+
+		return next(ctx)
+	}
+}
+
+// writeGraphQLError writes a GraphQL-spec-compliant errors body
+// ({"errors":[{"message":...,"extensions":{"complexity":N,"max":M}}]}) and a
+// 422 Unprocessable Entity status, signalling that the request was rejected
+// before execution rather than failing during it.
+func writeGraphQLError(w http.ResponseWriter, message string, complexityScore, max int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	_ = json.NewEncoder(w).Encode(graphQLErrorResponse{
+		Errors: []graphQLError{{
+			Message: message,
+			Extensions: map[string]interface{}{
+				"complexity": complexityScore,
+				"max":        max,
+			},
+		}},
+	})
+}
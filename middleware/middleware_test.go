@@ -0,0 +1,93 @@
+package middleware_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infor-cloud/martian-cloud/tharsis/graphql-query-complexity/middleware"
+)
+
+func doRequest(t *testing.T, mw func(http.Handler) http.Handler, query string) (*httptest.ResponseRecorder, bool) {
+	t.Helper()
+
+	var called bool
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		_, _ = middleware.ComplexityFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	body, err := json.Marshal(map[string]interface{}{"query": query})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	mw(next).ServeHTTP(rec, req)
+
+	return rec, called
+}
+
+func TestNewHTTPMiddleware(t *testing.T) {
+	query := `query{
+		group(fullPath: "colonies") {
+			name
+		}
+	  }`
+
+	t.Run("allows a request under the complexity budget", func(t *testing.T) {
+		mw := middleware.NewHTTPMiddleware(middleware.Options{MaxComplexity: 10})
+		rec, called := doRequest(t, mw, query)
+
+		assert.True(t, called)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("rejects a request over the complexity budget", func(t *testing.T) {
+		overBudget := `query{
+			group(fullPath: "colonies") {
+				parent {
+					id
+				}
+			}
+		  }`
+
+		rejecting := middleware.NewHTTPMiddleware(middleware.Options{MaxComplexity: 1})
+		rec, called := doRequest(t, rejecting, overBudget)
+
+		assert.False(t, called)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+		var resp struct {
+			Errors []struct {
+				Message    string                 `json:"message"`
+				Extensions map[string]interface{} `json:"extensions"`
+			} `json:"errors"`
+		}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Len(t, resp.Errors, 1)
+		assert.Equal(t, "query too complex", resp.Errors[0].Message)
+		assert.EqualValues(t, 1, resp.Errors[0].Extensions["max"])
+	})
+
+	t.Run("rejects a budget store reservation failure", func(t *testing.T) {
+		mw := middleware.NewHTTPMiddleware(middleware.Options{BudgetStore: denyingBudgetStore{}})
+		rec, called := doRequest(t, mw, query)
+
+		assert.False(t, called)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+}
+
+type denyingBudgetStore struct{}
+
+func (denyingBudgetStore) Reserve(_ context.Context, _ int) error {
+	return errors.New("budget exhausted")
+}
@@ -0,0 +1,167 @@
+package complexity
+
+import (
+	"fmt"
+
+	"github.com/graph-gophers/graphql-go/types"
+	"gitlab.com/infor-cloud/martian-cloud/tharsis/graphql-query-complexity/internal/query"
+)
+
+// Options configures GetQueryComplexityWithOptions, bundling field
+// overrides and limit enforcement with subscription-specific pricing so
+// callers that need all three don't have to thread extra parameters
+// through the call.
+type Options struct {
+	FieldOverrides map[string]int
+	Limits         *Limits
+
+	// OperationName selects which operation to evaluate when queryString
+	// contains more than one. It may be left empty for a document with a
+	// single operation; when set, it must name an operation in the
+	// document, or it is an error. Leaving it empty for a document with more
+	// than one operation is an error, mirroring how gqlgen and graphql-go resolve
+	// `operationName` for GraphQL-over-HTTP requests.
+	OperationName string
+
+	// SubscriptionComplexity is the base cost charged for a subscription
+	// operation, mirroring the fixed mutationComplexity cost for mutations.
+	// Zero uses the package default (subscriptionComplexity).
+	SubscriptionComplexity int
+
+	// SubscriptionConnectionMultiplier scales the complexity of connection
+	// fields selected directly in a subscription's payload, since a
+	// long-lived subscription re-evaluates its selection on every event it
+	// delivers. Connection fields nested deeper in the payload, or reached
+	// through a fragment spread, are not scaled.
+	// Zero uses the package default (no scaling).
+	SubscriptionConnectionMultiplier int
+}
+
+// GetQueryComplexityWithOptions behaves like GetQueryComplexityWithLimits,
+// but also lets callers price subscription operations distinctly from
+// queries and mutations, and pick one operation out of a multi-operation
+// document, via opts.
+func GetQueryComplexityWithOptions(queryString string, variables map[string]interface{}, opts *Options) (int, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	subCost := opts.SubscriptionComplexity
+	if subCost == 0 {
+		subCost = subscriptionComplexity
+	}
+	subConnMultiplier := opts.SubscriptionConnectionMultiplier
+	if subConnMultiplier == 0 {
+		subConnMultiplier = defaultSubscriptionConnectionMultiplier
+	}
+
+	// fragUsed initializes a map with a key: fragment name value: types.Fragment
+	fragUsed := make(map[string]types.SelectionSet)
+	// parse and lex the provided query string
+	executableDefinition, err := query.Parse(queryString)
+	if err != nil {
+		return 0, err
+	}
+	// creates map of fragments with name as key
+	for _, f := range executableDefinition.Fragments {
+		fragUsed[f.Name.Name] = f.Selections
+	}
+
+	op, selectErr := selectOperation(executableDefinition.Operations, opts.OperationName)
+	if selectErr != nil {
+		return 0, selectErr
+	}
+
+	aliasCount := 0
+	state := &queryState{
+		variables:                  variables,
+		fieldOverrides:             opts.FieldOverrides,
+		fragUsed:                   fragUsed,
+		limits:                     opts.Limits,
+		aliasCount:                 &aliasCount,
+		subscriptionConnMultiplier: subConnMultiplier,
+		visiting:                   make(map[string]bool),
+		fragComplexity:             make(map[string]int),
+	}
+
+	c, err := operationComplexity(op, state, subCost)
+	if err != nil {
+		return 0, err
+	}
+	// The walk only checks MaxComplexity against each selection set's
+	// running total; check the operation's full total here as well.
+	if err := checkComplexity(state, "", c); err != nil {
+		return 0, err
+	}
+	return c, nil
+}
+
+// GetOperationComplexities computes the complexity of every operation in
+// queryString independently, keyed by operation name (the empty string
+// keys a lone anonymous operation), so a gateway can log or budget each
+// operation in a multi-operation document rather than only the one
+// GetQueryComplexityWithOptions selects.
+func GetOperationComplexities(queryString string, variables map[string]interface{}, fieldOverrides map[string]int) (map[string]int, error) {
+	fragUsed := make(map[string]types.SelectionSet)
+	executableDefinition, err := query.Parse(queryString)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range executableDefinition.Fragments {
+		fragUsed[f.Name.Name] = f.Selections
+	}
+
+	complexities := make(map[string]int, len(executableDefinition.Operations))
+	for _, op := range executableDefinition.Operations {
+		aliasCount := 0
+		state := &queryState{
+			variables:                  variables,
+			fieldOverrides:             fieldOverrides,
+			fragUsed:                   fragUsed,
+			aliasCount:                 &aliasCount,
+			subscriptionConnMultiplier: defaultSubscriptionConnectionMultiplier,
+			visiting:                   make(map[string]bool),
+			fragComplexity:             make(map[string]int),
+		}
+
+		c, err := operationComplexity(op, state, subscriptionComplexity)
+		if err != nil {
+			return nil, err
+		}
+		complexities[op.Name.Name] = c
+	}
+	return complexities, nil
+}
+
+// selectOperation returns the operation queryString's caller wants
+// evaluated. When operationName is empty, operations must contain exactly
+// one entry (the common, single-operation case); otherwise the named
+// operation is looked up explicitly.
+func selectOperation(operations []*types.OperationDefinition, operationName string) (*types.OperationDefinition, error) {
+	if operationName == "" {
+		if len(operations) == 1 {
+			return operations[0], nil
+		}
+		return nil, fmt.Errorf("document has %d operations; operationName is required", len(operations))
+	}
+	for _, op := range operations {
+		if op.Name.Name == operationName {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("no operation named %q in document", operationName)
+}
+
+// operationComplexity dispatches to the complexity calculation for op's
+// operation type, pricing a subscription at subscriptionCost.
+func operationComplexity(op *types.OperationDefinition, state *queryState, subscriptionCost int) (int, error) {
+	switch op.Type {
+	case query.Query:
+		return calculateSelectionComplexity(op.Selections, state, 1, "")
+	case query.Mutation:
+		return calculateMutationComplexity(op.Selections, state)
+	case query.Subscription:
+		return calculateSubscriptionComplexity(op.Selections, state, subscriptionCost)
+	}
+	return 0, nil
+}
@@ -0,0 +1,167 @@
+package complexity_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	complexity "gitlab.com/infor-cloud/martian-cloud/tharsis/graphql-query-complexity"
+)
+
+func TestGetQueryComplexityWithOptions(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		opts           *complexity.Options
+		wantComplexity int
+	}{
+		{
+			name: "subscription uses the default base cost",
+			query: `subscription{
+				issueUpdated {
+					id
+					title
+				}
+			  }`,
+			wantComplexity: 15,
+		},
+		{
+			name: "subscription base cost is configurable",
+			query: `subscription{
+				issueUpdated {
+					id
+				}
+			  }`,
+			opts:           &complexity.Options{SubscriptionComplexity: 25},
+			wantComplexity: 25,
+		},
+		{
+			name: "connection fields in the payload are scaled by the multiplier",
+			query: `subscription{
+				issueUpdated {
+					comments(first: 5) {
+						edges {
+							node {
+								id
+							}
+						}
+					}
+				}
+			  }`,
+			opts:           &complexity.Options{SubscriptionConnectionMultiplier: 3},
+			wantComplexity: 15 + (3 * 7),
+		},
+		{
+			name: "nil options behave like the package defaults",
+			query: `subscription{
+				issueUpdated {
+					id
+				}
+			  }`,
+			opts:           nil,
+			wantComplexity: 15,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := complexity.GetQueryComplexityWithOptions(test.query, nil, test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, test.wantComplexity, actual)
+		})
+	}
+}
+
+func TestGetQueryComplexityWithOptions_OperationName(t *testing.T) {
+	multiOpQuery := `query GetGroup{
+			group(fullPath: "colonies") {
+				id
+			}
+		  }
+		  query GetUser{
+			me {
+				id
+			}
+		  }`
+
+	tests := []struct {
+		name           string
+		query          string
+		opts           *complexity.Options
+		wantComplexity int
+		wantErr        bool
+	}{
+		{
+			name:           "single operation ignores an unset operationName",
+			query:          `query{ group(fullPath: "colonies") { id } }`,
+			wantComplexity: 1,
+		},
+		{
+			name:           "named operation is selected out of a multi-operation document",
+			query:          multiOpQuery,
+			opts:           &complexity.Options{OperationName: "GetGroup"},
+			wantComplexity: 1,
+		},
+		{
+			name:           "a different named operation in the same document is selected independently",
+			query:          multiOpQuery,
+			opts:           &complexity.Options{OperationName: "GetUser"},
+			wantComplexity: 1,
+		},
+		{
+			name:    "multi-operation document with no operationName is an error",
+			query:   multiOpQuery,
+			wantErr: true,
+		},
+		{
+			name:    "operationName with no matching operation is an error",
+			query:   multiOpQuery,
+			opts:    &complexity.Options{OperationName: "DoesNotExist"},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := complexity.GetQueryComplexityWithOptions(test.query, nil, test.opts)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, test.wantComplexity, actual)
+		})
+	}
+}
+
+func TestGetOperationComplexities(t *testing.T) {
+	query := `query GetGroup{
+			group(fullPath: "colonies") {
+				id
+				members(first: 5) {
+					edges {
+						node {
+							id
+						}
+					}
+				}
+			}
+		  }
+		  query GetUser{
+			me {
+				id
+			}
+		  }`
+
+	actual, err := complexity.GetOperationComplexities(query, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[string]int{
+		"GetGroup": 8,
+		"GetUser":  1,
+	}, actual)
+}